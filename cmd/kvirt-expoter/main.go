@@ -3,32 +3,76 @@ package main
 import (
 	"flag"
 	"fmt"
-	"log"
 	"net/http"
+	"os"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/common/promslog"
 	"github.com/prometheus/common/version"
 	"github.com/isempty/kvirt-exporter/collector"
+	"github.com/isempty/kvirt-exporter/control"
+	libvirt "libvirt.org/go/libvirt"
 )
 
 var (
 	listenAddress = flag.String("web.listen-address", ":9257", "Address to listen on for web interface and telemetry.")
 	metricsPath   = flag.String("web.telemetry-path", "/metrics", "Path under which to expose metrics.")
+	libvirtURI    = flag.String("libvirt.uri", "qemu:///system", "libvirt connection URI to scrape.")
+
+	enableControl        = flag.Bool("web.enable-control", false, "Enable the POST /control endpoint for live-tuning VM cgroup limits. Off by default.")
+	controlListenAddress = flag.String("web.control-listen-address", ":9258", "Address to listen on for the control endpoint. Served on its own listener so it can be firewalled off separately from /metrics.")
+	controlBearerToken   = flag.String("web.control-bearer-token", "", "Bearer token required on POST /control. --web.enable-control refuses to start without it.")
+
+	logLevel  = promslog.NewLevel()
+	logFormat = promslog.NewFormat()
 )
 
 func main() {
+	flag.Var(logLevel, "log.level", "Only log messages with the given severity or above. One of: [debug, info, warn, error]")
+	flag.Var(logFormat, "log.format", "Output format of log messages. One of: [logfmt, json]")
 	flag.Parse()
 
+	logger := promslog.New(&promslog.Config{Level: logLevel, Format: logFormat})
+
 	// Prometheus 버전 정보 등록
 	prometheus.MustRegister(version.NewCollector("kvirt-exporter"))
 
-	// VM CPU 수집기 등록
-	collector, err := collector.NewVMCPUCollector()
+	// libvirt 연결은 한 번만 맺고 모든 수집기가 공유한다
+	conn, err := libvirt.NewConnect(*libvirtURI)
+	if err != nil {
+		logger.Error("failed to connect to libvirt", "uri", *libvirtURI, "err", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	kvirtCollector, err := collector.NewKvirtCollector(conn, logger)
 	if err != nil {
-		log.Fatalf("Failed to create collector: %v", err)
+		logger.Error("failed to create collector", "err", err)
+		os.Exit(1)
+	}
+	prometheus.MustRegister(kvirtCollector)
+
+	if *enableControl {
+		if *controlBearerToken == "" {
+			logger.Error("--web.enable-control requires --web.control-bearer-token")
+			os.Exit(1)
+		}
+		handler, err := control.NewHandler(*controlBearerToken)
+		if err != nil {
+			logger.Error("failed to create control handler", "err", err)
+			os.Exit(1)
+		}
+		controlMux := http.NewServeMux()
+		controlMux.Handle("/control", handler)
+		go func() {
+			logger.Info("starting control endpoint", "address", *controlListenAddress)
+			if err := http.ListenAndServe(*controlListenAddress, controlMux); err != nil {
+				logger.Error("control server failed", "err", err)
+				os.Exit(1)
+			}
+		}()
 	}
-	prometheus.MustRegister(collector)
 
 	// HTTP 서버 설정
 	http.Handle(*metricsPath, promhttp.Handler())
@@ -42,8 +86,9 @@ func main() {
 			</html>`, *metricsPath)
 	})
 
-	log.Printf("Starting VM CPU Exporter on %s", *listenAddress)
+	logger.Info("starting VM CPU Exporter", "address", *listenAddress)
 	if err := http.ListenAndServe(*listenAddress, nil); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+		logger.Error("server failed", "err", err)
+		os.Exit(1)
 	}
 }
@@ -1,239 +1,162 @@
+// Package collector implements the kvirt-exporter Prometheus collectors.
+//
+// It follows the node_exporter pattern: individual sub-collectors register
+// themselves in an init() function, each gated behind its own
+// --collector.<name> flag, and KvirtCollector fans a scrape out to whichever
+// of them are enabled.
 package collector
 
 import (
+	"flag"
 	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"log/slog"
 	"strconv"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	libvirt "libvirt.org/go/libvirt"
 )
 
-type VMCPUCollector struct {
-	userUsage   *prometheus.GaugeVec
-	systemUsage *prometheus.GaugeVec
-	iowaitUsage *prometheus.GaugeVec
-	tick        int64
+const (
+	namespace = "kvirt"
+
+	defaultEnabled  = true
+	defaultDisabled = false
+)
+
+var (
+	scrapeDurationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "scrape", "collector_duration_seconds"),
+		"kvirt_exporter: Duration of the most recent scrape of a collector.",
+		[]string{"collector"}, nil,
+	)
+	scrapeSuccessDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "scrape", "collector_success"),
+		"kvirt_exporter: Whether a collector succeeded.",
+		[]string{"collector"}, nil,
+	)
+
+	disableDefaultCollectors = flag.Bool("collector.disable-defaults", false, "Set all collectors to disabled by default.")
+)
+
+// Collector is implemented by every sub-collector that the KvirtCollector
+// fans scrapes out to.
+type Collector interface {
+	// Update gathers one round of metrics for this collector and sends
+	// them on ch. It may be called concurrently with other collectors'
+	// Update methods, so it must not touch shared mutable state.
+	Update(ch chan<- prometheus.Metric) error
 }
 
-func NewVMCPUCollector() (*VMCPUCollector, error) {
-	tick, err := getClockTick()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get CLK_TCK: %v", err)
-	}
+type factoryFunc func(conn *libvirt.Connect, logger *slog.Logger) (Collector, error)
 
-	return &VMCPUCollector{
-		userUsage: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "vm_cpu_user_percent",
-				Help: "User CPU usage percentage for VM",
-			},
-			[]string{"vm"},
-		),
-		systemUsage: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "vm_cpu_system_percent",
-				Help: "System CPU usage percentage for VM",
-			},
-			[]string{"vm"},
-		),
-		iowaitUsage: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "vm_cpu_iowait_percent",
-				Help: "Iowait CPU usage percentage for VM",
-			},
-			[]string{"vm"},
-		),
-		tick: tick,
-	}, nil
+// enabledFlag is a tri-state --collector.<name> flag: unset (follow the
+// collector's default, unless overridden by --collector.disable-defaults),
+// or explicitly true/false.
+type enabledFlag struct {
+	explicit *bool
 }
 
-func (c *VMCPUCollector) Describe(ch chan<- *prometheus.Desc) {
-	c.userUsage.Describe(ch)
-	c.systemUsage.Describe(ch)
-	c.iowaitUsage.Describe(ch)
+func (f *enabledFlag) String() string {
+	if f.explicit == nil {
+		return ""
+	}
+	return strconv.FormatBool(*f.explicit)
 }
 
-func (c *VMCPUCollector) Collect(ch chan<- prometheus.Metric) {
-	vmList, err := getVMList()
+func (f *enabledFlag) Set(s string) error {
+	b, err := strconv.ParseBool(s)
 	if err != nil {
-		fmt.Printf("Error getting VM list: %v\n", err)
-		return
+		return err
 	}
+	f.explicit = &b
+	return nil
+}
 
-	for _, vm := range vmList {
-		vcpuCount, err := getVCPUCount(vm)
-		if err != nil || vcpuCount == 0 {
-			fmt.Printf("Error getting vCPU count for %s: %v\n", vm, err)
-			continue
-		}
+// IsBoolFlag lets "--collector.foo" on its own mean "--collector.foo=true".
+func (f *enabledFlag) IsBoolFlag() bool { return true }
 
-		pid, err := getQEMUPID(vm)
-		if err != nil || pid == "" {
-			fmt.Printf("Error getting QEMU PID for %s: %v\n", vm, err)
-			continue
-		}
+type registration struct {
+	isDefaultEnabled bool
+	enabled          *enabledFlag
+	factory          factoryFunc
+}
 
-		// 첫 번째 스냅샷
-		utime1, stime1, err := getCPUStats(pid)
-		if err != nil {
-			fmt.Printf("Error getting CPU stats for %s: %v\n", vm, err)
-			continue
-		}
-		iowait1, err := getIOWait()
-		if err != nil {
-			fmt.Printf("Error getting iowait for %s: %v\n", vm, err)
-			continue
-		}
+var collectors = make(map[string]*registration)
 
-		// 0.1초 대기
-		time.Sleep(100 * time.Millisecond)
+// registerCollector is called from each sub-collector's init() to wire up
+// its --collector.<name> flag and make it available to NewKvirtCollector.
+func registerCollector(name string, isDefaultEnabled bool, factory factoryFunc) {
+	e := &enabledFlag{}
+	flag.Var(e, fmt.Sprintf("collector.%s", name), fmt.Sprintf("Enable the %s collector.", name))
+	collectors[name] = &registration{isDefaultEnabled: isDefaultEnabled, enabled: e, factory: factory}
+}
 
-		// 두 번째 스냅샷
-		utime2, stime2, err := getCPUStats(pid)
-		if err != nil {
-			fmt.Printf("Error getting second CPU stats for %s: %v\n", vm, err)
+// KvirtCollector fans a single Prometheus scrape out to every enabled
+// sub-collector in parallel.
+type KvirtCollector struct {
+	collectors map[string]Collector
+	logger     *slog.Logger
+}
+
+// NewKvirtCollector builds the set of enabled sub-collectors on top of the
+// shared libvirt connection conn. Each sub-collector gets logger with a
+// "collector" attribute set to its registered name.
+func NewKvirtCollector(conn *libvirt.Connect, logger *slog.Logger) (*KvirtCollector, error) {
+	enabled := make(map[string]Collector)
+	for name, reg := range collectors {
+		if !isEnabled(reg) {
 			continue
 		}
-		iowait2, err := getIOWait()
+		c, err := reg.factory(conn, logger.With("collector", name))
 		if err != nil {
-			fmt.Printf("Error getting second iowait for %s: %v\n", vm, err)
-			continue
-		}
-
-		// 차이 계산
-		utimeDiff := utime2 - utime1
-		stimeDiff := stime2 - stime1
-		iowaitDiff := iowait2 - iowait1
-
-		// 총 가용 시간 (0.1초 * vCPU 수)
-		totalInterval := float64(c.tick) / 10 * float64(vcpuCount)
-
-		// 백분율 계산
-		userPct := float64(utimeDiff) * 100 / totalInterval
-		systemPct := float64(stimeDiff) * 100 / totalInterval
-		iowaitPct := float64(iowaitDiff) * 100 / totalInterval
-
-		// 음수 방지
-		if userPct < 0 {
-			userPct = 0
+			return nil, fmt.Errorf("couldn't create collector %s: %v", name, err)
 		}
-		if systemPct < 0 {
-			systemPct = 0
-		}
-		if iowaitPct < 0 {
-			iowaitPct = 0
-		}
-
-		// 메트릭 설정
-		c.userUsage.WithLabelValues(vm).Set(userPct)
-		c.systemUsage.WithLabelValues(vm).Set(systemPct)
-		c.iowaitUsage.WithLabelValues(vm).Set(iowaitPct)
-
-		fmt.Printf("%s | user: %.2f%% | system: %.2f%% | iowait: %.2f%%\n", vm, userPct, systemPct, iowaitPct)
+		enabled[name] = c
 	}
-
-	c.userUsage.Collect(ch)
-	c.systemUsage.Collect(ch)
-	c.iowaitUsage.Collect(ch)
+	return &KvirtCollector{collectors: enabled, logger: logger}, nil
 }
 
-func getClockTick() (int64, error) {
-	tick, err := strconv.ParseInt(fmt.Sprintf("%d", os.Sysconf(os.SysconfName(_SC_CLK_TCK))), 10, 64)
-	if err != nil {
-		return 0, err
+func isEnabled(reg *registration) bool {
+	if reg.enabled.explicit != nil {
+		return *reg.enabled.explicit
 	}
-	return tick, nil
-}
-
-func getVMList() ([]string, error) {
-	cmd := exec.Command("virsh", "list", "--name")
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, err
-	}
-	vms := strings.Split(strings.TrimSpace(string(output)), "\n")
-	var result []string
-	for _, vm := range vms {
-		if vm != "" {
-			result = append(result, vm)
-		}
+	if *disableDefaultCollectors {
+		return false
 	}
-	return result, nil
+	return reg.isDefaultEnabled
 }
 
-func getVCPUCount(vm string) (int, error) {
-	cmd := exec.Command("virsh", "dominfo", vm)
-	output, err := cmd.Output()
-	if err != nil {
-		return 0, err
-	}
-	for _, line := range strings.Split(string(output), "\n") {
-		if strings.Contains(line, "CPU(s)") {
-			parts := strings.Fields(line)
-			if len(parts) > 1 {
-				return strconv.Atoi(parts[1])
-			}
-		}
-	}
-	return 0, nil
+func (n *KvirtCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- scrapeDurationDesc
+	ch <- scrapeSuccessDesc
 }
 
-func getQEMUPID(vm string) (string, error) {
-	cmd := exec.Command("ps", "aux")
-	output, err := cmd.Output()
-	if err != nil {
-		return "", err
-	}
-	for _, line := range strings.Split(string(output), "\n") {
-		if strings.Contains(line, "qemu-system") && strings.Contains(line, vm) {
-			parts := strings.Fields(line)
-			if len(parts) > 1 {
-				return parts[1], nil
-			}
-		}
+func (n *KvirtCollector) Collect(ch chan<- prometheus.Metric) {
+	var wg sync.WaitGroup
+	wg.Add(len(n.collectors))
+	for name, c := range n.collectors {
+		go func(name string, c Collector) {
+			defer wg.Done()
+			n.execute(name, c, ch)
+		}(name, c)
 	}
-	return "", nil
+	wg.Wait()
 }
 
-func getCPUStats(pid string) (int64, int64, error) {
-	var totalUtime, totalStime int64
-	tasks, err := filepath.Glob(fmt.Sprintf("/proc/%s/task/*/stat", pid))
-	if err != nil {
-		return 0, 0, err
-	}
-	for _, task := range tasks {
-		data, err := os.ReadFile(task)
-		if err != nil {
-			continue
-		}
-		parts := strings.Fields(string(data))
-		if len(parts) < 15 {
-			continue
-		}
-		utime, _ := strconv.ParseInt(parts[13], 10, 64)
-		stime, _ := strconv.ParseInt(parts[14], 10, 64)
-		totalUtime += utime
-		totalStime += stime
-	}
-	return totalUtime, totalStime, nil
-}
+func (n *KvirtCollector) execute(name string, c Collector, ch chan<- prometheus.Metric) {
+	begin := time.Now()
+	err := c.Update(ch)
+	duration := time.Since(begin).Seconds()
 
-func getIOWait() (int64, error) {
-	data, err := os.ReadFile("/proc/stat")
+	var success float64
 	if err != nil {
-		return 0, err
+		n.logger.Error("collector failed", "collector", name, "err", err)
+	} else {
+		success = 1
 	}
-	for _, line := range strings.Split(string(data), "\n") {
-		if strings.HasPrefix(line, "cpu ") {
-			parts := strings.Fields(line)
-			if len(parts) > 5 {
-				return strconv.ParseInt(parts[5], 10, 64)
-			}
-		}
-	}
-	return 0, nil
+
+	ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, duration, name)
+	ch <- prometheus.MustNewConstMetric(scrapeSuccessDesc, prometheus.GaugeValue, success, name)
 }
@@ -1,31 +1,85 @@
 package collector
 
 import (
+	"io"
+	"log/slog"
 	"testing"
 
 	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
+	libvirt "libvirt.org/go/libvirt"
 )
 
+// testLibvirtURI points at libvirt's built-in test driver so these tests
+// don't need a real hypervisor connection.
+const testLibvirtURI = "test:///default"
+
+func testConnect(t *testing.T) *libvirt.Connect {
+	t.Helper()
+	conn, err := libvirt.NewConnect(testLibvirtURI)
+	if err != nil {
+		t.Fatalf("Failed to connect to %s: %v", testLibvirtURI, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
 func TestNewVMCPUCollector(t *testing.T) {
-	collector, err := NewVMCPUCollector()
+	c, err := NewVMCPUCollector(testConnect(t), testLogger())
 	if err != nil {
 		t.Fatalf("Failed to create collector: %v", err)
 	}
-	if collector == nil {
+	if c == nil {
 		t.Fatal("Collector is nil")
 	}
 }
 
-func TestCollect(t *testing.T) {
-	collector, err := NewVMCPUCollector()
+func TestVMCPUCollectorUpdate(t *testing.T) {
+	c, err := NewVMCPUCollector(testConnect(t), testLogger())
 	if err != nil {
 		t.Fatalf("Failed to create collector: %v", err)
 	}
+	testUpdate(t, c)
+}
+
+func TestVMMemoryCollectorUpdate(t *testing.T) {
+	c, err := NewVMMemoryCollector(testConnect(t), testLogger())
+	if err != nil {
+		t.Fatalf("Failed to create collector: %v", err)
+	}
+	testUpdate(t, c)
+}
+
+func TestVMBlockCollectorUpdate(t *testing.T) {
+	c, err := NewVMBlockCollector(testConnect(t), testLogger())
+	if err != nil {
+		t.Fatalf("Failed to create collector: %v", err)
+	}
+	testUpdate(t, c)
+}
+
+func TestVMNetCollectorUpdate(t *testing.T) {
+	c, err := NewVMNetCollector(testConnect(t), testLogger())
+	if err != nil {
+		t.Fatalf("Failed to create collector: %v", err)
+	}
+	testUpdate(t, c)
+}
+
+// testUpdate drains a collector's Update, failing the test if Update errors
+// or emits a metric that fails to serialize.
+func testUpdate(t *testing.T, c Collector) {
+	t.Helper()
 
 	ch := make(chan prometheus.Metric)
 	go func() {
-		collector.Collect(ch)
+		if err := c.Update(ch); err != nil {
+			t.Errorf("Update failed: %v", err)
+		}
 		close(ch)
 	}()
 
@@ -0,0 +1,99 @@
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/prometheus/client_golang/prometheus"
+	libvirt "libvirt.org/go/libvirt"
+)
+
+func init() {
+	registerCollector("vm_cpu", defaultEnabled, NewVMCPUCollector)
+}
+
+// VMCPUCollector collects per-VM CPU time counters directly from libvirt,
+// so Prometheus (not the collector) is responsible for turning them into
+// rates.
+type VMCPUCollector struct {
+	conn   *libvirt.Connect
+	logger *slog.Logger
+
+	cpuUserTime   *prometheus.Desc
+	cpuSystemTime *prometheus.Desc
+	vcpuTime      *prometheus.Desc
+}
+
+// NewVMCPUCollector builds a Collector that reuses the shared libvirt
+// connection conn.
+func NewVMCPUCollector(conn *libvirt.Connect, logger *slog.Logger) (Collector, error) {
+	return &VMCPUCollector{
+		conn:   conn,
+		logger: logger,
+		cpuUserTime: prometheus.NewDesc(
+			"kvirt_vm_cpu_time_user_seconds_total",
+			"Cumulative user CPU time consumed by the VM, in seconds.",
+			[]string{"vm"}, nil,
+		),
+		cpuSystemTime: prometheus.NewDesc(
+			"kvirt_vm_cpu_time_system_seconds_total",
+			"Cumulative system CPU time consumed by the VM, in seconds.",
+			[]string{"vm"}, nil,
+		),
+		vcpuTime: prometheus.NewDesc(
+			"kvirt_vm_vcpu_time_seconds_total",
+			"Cumulative CPU time consumed by an individual vCPU, in seconds.",
+			[]string{"vm", "vcpu"}, nil,
+		),
+	}, nil
+}
+
+func (c *VMCPUCollector) Update(ch chan<- prometheus.Metric) error {
+	domains, err := c.conn.ListAllDomains(libvirt.CONNECT_LIST_DOMAINS_ACTIVE)
+	if err != nil {
+		return fmt.Errorf("failed to list domains: %v", err)
+	}
+
+	for _, domain := range domains {
+		c.updateDomain(ch, domain)
+		domain.Free()
+	}
+	return nil
+}
+
+func (c *VMCPUCollector) updateDomain(ch chan<- prometheus.Metric, domain libvirt.Domain) {
+	name, err := domain.GetName()
+	if err != nil {
+		c.logger.Error("failed to get domain name", "err", err)
+		return
+	}
+
+	params := make([]libvirt.DomainCPUStats, 1)
+	if _, err := domain.GetCPUStats(params, 2, -1, 1, 0); err != nil {
+		c.logger.Error("failed to get CPU stats", "vm", name, "err", err)
+		return
+	}
+	if params[0].UserSet {
+		ch <- prometheus.MustNewConstMetric(c.cpuUserTime, prometheus.CounterValue, nsToSeconds(params[0].User), name)
+	}
+	if params[0].SystemSet {
+		ch <- prometheus.MustNewConstMetric(c.cpuSystemTime, prometheus.CounterValue, nsToSeconds(params[0].System), name)
+	}
+
+	vcpus, _, err := domain.GetVcpus()
+	if err != nil {
+		c.logger.Error("failed to get vCPU info", "vm", name, "err", err)
+		return
+	}
+	for _, vcpu := range vcpus {
+		c.logger.Debug("vcpu time", "vm", name, "vcpu", vcpu.Number, "cpu_time_ns", vcpu.CpuTime)
+		ch <- prometheus.MustNewConstMetric(
+			c.vcpuTime, prometheus.CounterValue, nsToSeconds(vcpu.CpuTime),
+			name, fmt.Sprintf("%d", vcpu.Number),
+		)
+	}
+}
+
+func nsToSeconds(ns uint64) float64 {
+	return float64(ns) / 1e9
+}
@@ -0,0 +1,30 @@
+package collector
+
+import "encoding/xml"
+
+// domainDevices is the slice of a domain's XML description that the block
+// and net collectors need to discover the disk and interface device names
+// to pass to virDomainBlockStats/virDomainInterfaceStats.
+type domainDevices struct {
+	XMLName xml.Name `xml:"domain"`
+	Devices struct {
+		Disks []struct {
+			Target struct {
+				Dev string `xml:"dev,attr"`
+			} `xml:"target"`
+		} `xml:"disk"`
+		Interfaces []struct {
+			Target struct {
+				Dev string `xml:"dev,attr"`
+			} `xml:"target"`
+		} `xml:"interface"`
+	} `xml:"devices"`
+}
+
+func parseDomainDevices(desc string) (domainDevices, error) {
+	var d domainDevices
+	if err := xml.Unmarshal([]byte(desc), &d); err != nil {
+		return d, err
+	}
+	return d, nil
+}
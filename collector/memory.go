@@ -0,0 +1,90 @@
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/prometheus/client_golang/prometheus"
+	libvirt "libvirt.org/go/libvirt"
+)
+
+func init() {
+	registerCollector("vm_memory", defaultEnabled, NewVMMemoryCollector)
+}
+
+// memoryStatLabels maps the libvirt memory stat tags we care about to the
+// "stat" label value used on kvirt_vm_memory_stat_bytes.
+var memoryStatLabels = map[int32]string{
+	libvirt.DOMAIN_MEMORY_STAT_SWAP_IN:        "swap_in",
+	libvirt.DOMAIN_MEMORY_STAT_SWAP_OUT:       "swap_out",
+	libvirt.DOMAIN_MEMORY_STAT_MAJOR_FAULT:    "major_fault",
+	libvirt.DOMAIN_MEMORY_STAT_MINOR_FAULT:    "minor_fault",
+	libvirt.DOMAIN_MEMORY_STAT_UNUSED:         "unused",
+	libvirt.DOMAIN_MEMORY_STAT_AVAILABLE:      "available",
+	libvirt.DOMAIN_MEMORY_STAT_ACTUAL_BALLOON: "actual_balloon",
+	libvirt.DOMAIN_MEMORY_STAT_RSS:            "rss",
+	libvirt.DOMAIN_MEMORY_STAT_USABLE:         "usable",
+}
+
+// VMMemoryCollector collects per-VM memory balloon stats via
+// virDomainMemoryStats.
+type VMMemoryCollector struct {
+	conn   *libvirt.Connect
+	logger *slog.Logger
+
+	memoryStat *prometheus.Desc
+}
+
+// NewVMMemoryCollector builds a Collector that reuses the shared libvirt
+// connection conn.
+func NewVMMemoryCollector(conn *libvirt.Connect, logger *slog.Logger) (Collector, error) {
+	return &VMMemoryCollector{
+		conn:   conn,
+		logger: logger,
+		memoryStat: prometheus.NewDesc(
+			"kvirt_vm_memory_stat_bytes",
+			"Memory balloon statistic for the VM, in bytes (or a count of page faults for the fault counters).",
+			[]string{"vm", "stat"}, nil,
+		),
+	}, nil
+}
+
+func (c *VMMemoryCollector) Update(ch chan<- prometheus.Metric) error {
+	domains, err := c.conn.ListAllDomains(libvirt.CONNECT_LIST_DOMAINS_ACTIVE)
+	if err != nil {
+		return fmt.Errorf("failed to list domains: %v", err)
+	}
+
+	for _, domain := range domains {
+		c.updateDomain(ch, domain)
+		domain.Free()
+	}
+	return nil
+}
+
+func (c *VMMemoryCollector) updateDomain(ch chan<- prometheus.Metric, domain libvirt.Domain) {
+	name, err := domain.GetName()
+	if err != nil {
+		c.logger.Error("failed to get domain name", "err", err)
+		return
+	}
+
+	stats, err := domain.MemoryStats(uint32(libvirt.DOMAIN_MEMORY_STAT_NR), 0)
+	if err != nil {
+		c.logger.Error("failed to get memory stats", "vm", name, "err", err)
+		return
+	}
+
+	for _, stat := range stats {
+		label, ok := memoryStatLabels[int32(stat.Tag)]
+		if !ok {
+			continue
+		}
+		val := float64(stat.Val)
+		if label != "major_fault" && label != "minor_fault" {
+			// libvirt reports the size stats in KiB.
+			val *= 1024
+		}
+		ch <- prometheus.MustNewConstMetric(c.memoryStat, prometheus.GaugeValue, val, name, label)
+	}
+}
@@ -0,0 +1,110 @@
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/prometheus/client_golang/prometheus"
+	libvirt "libvirt.org/go/libvirt"
+)
+
+func init() {
+	registerCollector("vm_net", defaultEnabled, NewVMNetCollector)
+}
+
+// VMNetCollector collects per-VM, per-interface network counters via
+// virDomainInterfaceStats.
+type VMNetCollector struct {
+	conn   *libvirt.Connect
+	logger *slog.Logger
+
+	receiveBytes    *prometheus.Desc
+	transmitBytes   *prometheus.Desc
+	receivePackets  *prometheus.Desc
+	transmitPackets *prometheus.Desc
+}
+
+// NewVMNetCollector builds a Collector that reuses the shared libvirt
+// connection conn.
+func NewVMNetCollector(conn *libvirt.Connect, logger *slog.Logger) (Collector, error) {
+	labels := []string{"vm", "interface"}
+	return &VMNetCollector{
+		conn:   conn,
+		logger: logger,
+		receiveBytes: prometheus.NewDesc(
+			"kvirt_vm_net_receive_bytes_total",
+			"Cumulative bytes received on the interface by the VM.",
+			labels, nil,
+		),
+		transmitBytes: prometheus.NewDesc(
+			"kvirt_vm_net_transmit_bytes_total",
+			"Cumulative bytes transmitted on the interface by the VM.",
+			labels, nil,
+		),
+		receivePackets: prometheus.NewDesc(
+			"kvirt_vm_net_receive_packets_total",
+			"Cumulative packets received on the interface by the VM.",
+			labels, nil,
+		),
+		transmitPackets: prometheus.NewDesc(
+			"kvirt_vm_net_transmit_packets_total",
+			"Cumulative packets transmitted on the interface by the VM.",
+			labels, nil,
+		),
+	}, nil
+}
+
+func (c *VMNetCollector) Update(ch chan<- prometheus.Metric) error {
+	domains, err := c.conn.ListAllDomains(libvirt.CONNECT_LIST_DOMAINS_ACTIVE)
+	if err != nil {
+		return fmt.Errorf("failed to list domains: %v", err)
+	}
+
+	for _, domain := range domains {
+		c.updateDomain(ch, domain)
+		domain.Free()
+	}
+	return nil
+}
+
+func (c *VMNetCollector) updateDomain(ch chan<- prometheus.Metric, domain libvirt.Domain) {
+	name, err := domain.GetName()
+	if err != nil {
+		c.logger.Error("failed to get domain name", "err", err)
+		return
+	}
+
+	desc, err := domain.GetXMLDesc(0)
+	if err != nil {
+		c.logger.Error("failed to get XML description", "vm", name, "err", err)
+		return
+	}
+	devices, err := parseDomainDevices(desc)
+	if err != nil {
+		c.logger.Error("failed to parse domain XML", "vm", name, "err", err)
+		return
+	}
+
+	for _, iface := range devices.Devices.Interfaces {
+		if iface.Target.Dev == "" {
+			continue
+		}
+		stats, err := domain.InterfaceStats(iface.Target.Dev)
+		if err != nil {
+			c.logger.Error("failed to get interface stats", "vm", name, "interface", iface.Target.Dev, "err", err)
+			continue
+		}
+		if stats.RxBytesSet {
+			ch <- prometheus.MustNewConstMetric(c.receiveBytes, prometheus.CounterValue, float64(stats.RxBytes), name, iface.Target.Dev)
+		}
+		if stats.TxBytesSet {
+			ch <- prometheus.MustNewConstMetric(c.transmitBytes, prometheus.CounterValue, float64(stats.TxBytes), name, iface.Target.Dev)
+		}
+		if stats.RxPacketsSet {
+			ch <- prometheus.MustNewConstMetric(c.receivePackets, prometheus.CounterValue, float64(stats.RxPackets), name, iface.Target.Dev)
+		}
+		if stats.TxPacketsSet {
+			ch <- prometheus.MustNewConstMetric(c.transmitPackets, prometheus.CounterValue, float64(stats.TxPackets), name, iface.Target.Dev)
+		}
+	}
+}
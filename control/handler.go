@@ -0,0 +1,138 @@
+// Package control implements an optional HTTP endpoint that lets an
+// operator live-tune the cgroup limits of a running QEMU domain by talking
+// to its machine-qemu-*.scope systemd unit over dbus.
+package control
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	sddbus "github.com/coreos/go-systemd/v22/dbus"
+)
+
+// Handler serves POST /control requests that adjust a VM's systemd scope
+// properties. It is not registered by default; callers must opt in via
+// --web.enable-control and provide a bearer token.
+type Handler struct {
+	systemd     *sddbus.Conn
+	bearerToken string
+}
+
+// NewHandler connects to the system dbus and returns a Handler gated by
+// bearerToken. bearerToken must be non-empty; ServeHTTP rejects every
+// request if it is empty.
+func NewHandler(bearerToken string) (*Handler, error) {
+	conn, err := sddbus.NewSystemConnectionContext(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to systemd dbus: %v", err)
+	}
+	return &Handler{systemd: conn, bearerToken: bearerToken}, nil
+}
+
+type controlRequest struct {
+	VM       string `json:"vm"`
+	Property string `json:"property"`
+	Value    string `json:"value"`
+	Runtime  bool   `json:"runtime"`
+}
+
+type controlResponse struct {
+	OK    bool   `json:"ok"`
+	Unit  string `json:"unit,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.authorized(r) {
+		h.respond(w, http.StatusUnauthorized, controlResponse{Error: "unauthorized"})
+		return
+	}
+
+	var req controlRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respond(w, http.StatusBadRequest, controlResponse{Error: fmt.Sprintf("invalid request body: %v", err)})
+		return
+	}
+
+	unit, err := h.apply(r.Context(), req)
+	if err != nil {
+		h.respond(w, http.StatusBadRequest, controlResponse{Error: err.Error()})
+		return
+	}
+	h.respond(w, http.StatusOK, controlResponse{OK: true, Unit: unit})
+}
+
+func (h *Handler) apply(ctx context.Context, req controlRequest) (string, error) {
+	if req.VM == "" {
+		return "", fmt.Errorf("vm is required")
+	}
+	prop, err := buildProperty(req.Property, req.Value)
+	if err != nil {
+		return "", err
+	}
+	unit, err := h.findScope(ctx, req.VM)
+	if err != nil {
+		return "", err
+	}
+	if err := h.systemd.SetUnitPropertiesContext(ctx, unit, req.Runtime, prop); err != nil {
+		return "", fmt.Errorf("failed to set %s on %s: %v", req.Property, unit, err)
+	}
+	return unit, nil
+}
+
+// findScope resolves a VM name to its "machine-qemu-<id>-<vm>.scope"
+// systemd-machined unit, since the numeric id is assigned at VM start and
+// isn't otherwise known to us.
+func (h *Handler) findScope(ctx context.Context, vm string) (string, error) {
+	units, err := h.systemd.ListUnitsContext(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to list systemd units: %v", err)
+	}
+	names := make([]string, len(units))
+	for i, unit := range units {
+		names[i] = unit.Name
+	}
+	return matchScope(names, vm)
+}
+
+// matchScope picks the "machine-qemu-<id>-<vm>.scope" unit belonging to vm
+// out of names. It's split out from findScope so the matching logic can be
+// tested without a live systemd dbus connection.
+func matchScope(names []string, vm string) (string, error) {
+	pattern, err := regexp.Compile(fmt.Sprintf(`^machine-qemu-\d+-%s\.scope$`, regexp.QuoteMeta(vm)))
+	if err != nil {
+		return "", err
+	}
+	for _, name := range names {
+		if pattern.MatchString(name) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("no machine scope found for vm %q", vm)
+}
+
+func (h *Handler) authorized(r *http.Request) bool {
+	if h.bearerToken == "" {
+		return false
+	}
+	const prefix = "Bearer "
+	auth := r.Header.Get("Authorization")
+	if len(auth) != len(prefix)+len(h.bearerToken) || auth[:len(prefix)] != prefix {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(auth[len(prefix):]), []byte(h.bearerToken)) == 1
+}
+
+func (h *Handler) respond(w http.ResponseWriter, status int, resp controlResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,137 @@
+package control
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestServeHTTPMethodNotAllowed(t *testing.T) {
+	h := &Handler{bearerToken: "secret"}
+
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+		req := httptest.NewRequest(method, "/control", nil)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s /control = %d, want %d", method, rec.Code, http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func TestServeHTTPUnauthorized(t *testing.T) {
+	h := &Handler{bearerToken: "secret"}
+
+	tests := []struct {
+		name   string
+		header string
+	}{
+		{name: "missing header", header: ""},
+		{name: "garbage token", header: "Bearer not-the-secret"},
+		{name: "wrong scheme", header: "Basic secret"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/control", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("ServeHTTP() = %d, want %d", rec.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+func TestAuthorized(t *testing.T) {
+	h := &Handler{bearerToken: "secret"}
+
+	tests := []struct {
+		name string
+		auth string
+		want bool
+	}{
+		{name: "correct token", auth: "Bearer secret", want: true},
+		{name: "no header", auth: "", want: false},
+		{name: "wrong token", auth: "Bearer secret2", want: false},
+		{name: "prefix of token", auth: "Bearer secre", want: false},
+		{name: "missing scheme", auth: "secret", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/control", nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			if got := h.authorized(req); got != tt.want {
+				t.Errorf("authorized() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizedEmptyBearerToken(t *testing.T) {
+	h := &Handler{bearerToken: ""}
+	req := httptest.NewRequest(http.MethodPost, "/control", nil)
+	if h.authorized(req) {
+		t.Error("authorized() = true with empty server-side token, want false")
+	}
+}
+
+func TestMatchScope(t *testing.T) {
+	units := []string{
+		"machine-qemu-1-web.scope",
+		"machine-qemu-2-web2.scope",
+		"machine-qemu-3-myweb.scope",
+		"some-other.service",
+	}
+
+	tests := []struct {
+		name    string
+		vm      string
+		want    string
+		wantErr bool
+	}{
+		{name: "exact match", vm: "web", want: "machine-qemu-1-web.scope"},
+		{name: "does not match on a prefix of the vm name", vm: "we", wantErr: true},
+		{name: "other vm with shared suffix", vm: "myweb", want: "machine-qemu-3-myweb.scope"},
+		{name: "no match", vm: "nonexistent", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matchScope(units, tt.vm)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("matchScope(%q) = %q, want error", tt.vm, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("matchScope(%q) returned unexpected error: %v", tt.vm, err)
+			}
+			if got != tt.want {
+				t.Fatalf("matchScope(%q) = %q, want %q", tt.vm, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchScopeDoesNotFalseMatchSubstringVM(t *testing.T) {
+	// "web" must not match the unit for "web2", and "web2" must not match
+	// a unit that happens to contain "web" as a substring elsewhere.
+	units := []string{"machine-qemu-7-web2.scope"}
+
+	if got, err := matchScope(units, "web"); err == nil {
+		t.Errorf(`matchScope(units, "web") = %q, want error (false-matched web2's unit)`, got)
+	}
+
+	units = []string{"machine-qemu-7-web.scope"}
+	if got, err := matchScope(units, "web2"); err == nil {
+		t.Errorf(`matchScope(units, "web2") = %q, want error (false-matched web's unit)`, got)
+	}
+}
@@ -0,0 +1,97 @@
+package control
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	sddbus "github.com/coreos/go-systemd/v22/dbus"
+	"github.com/godbus/dbus/v5"
+)
+
+// allowedProperties is the set of systemd scope properties the control
+// endpoint is willing to change. Anything else is rejected before we ever
+// touch dbus.
+var allowedProperties = map[string]func(value string) (sddbus.Property, error){
+	"CPUAccounting":      boolProperty("CPUAccounting"),
+	"MemoryAccounting":   boolProperty("MemoryAccounting"),
+	"CPUQuotaPerSecUSec": durationProperty("CPUQuotaPerSecUSec"),
+	"MemoryHigh":         byteProperty("MemoryHigh"),
+	"MemoryMax":          byteProperty("MemoryMax"),
+}
+
+func buildProperty(name, value string) (sddbus.Property, error) {
+	build, ok := allowedProperties[name]
+	if !ok {
+		return sddbus.Property{}, fmt.Errorf("unsupported property %q", name)
+	}
+	return build(value)
+}
+
+func boolProperty(name string) func(string) (sddbus.Property, error) {
+	return func(value string) (sddbus.Property, error) {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return sddbus.Property{}, fmt.Errorf("%s expects a bool: %v", name, err)
+		}
+		return sddbus.Property{Name: name, Value: dbus.MakeVariant(b)}, nil
+	}
+}
+
+// durationProperty parses a Go duration string (e.g. "500ms") into the
+// microsecond uint64 systemd's *PerSecUSec properties expect.
+func durationProperty(name string) func(string) (sddbus.Property, error) {
+	return func(value string) (sddbus.Property, error) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return sddbus.Property{}, fmt.Errorf("%s expects a duration: %v", name, err)
+		}
+		if d < 0 {
+			return sddbus.Property{}, fmt.Errorf("%s expects a non-negative duration, got %s", name, d)
+		}
+		return sddbus.Property{Name: name, Value: dbus.MakeVariant(uint64(d.Microseconds()))}, nil
+	}
+}
+
+// byteProperty parses either "infinity" or a plain/suffixed (K/M/G/T, base
+// 1024) byte quantity into the uint64 systemd's memory properties expect.
+func byteProperty(name string) func(string) (sddbus.Property, error) {
+	return func(value string) (sddbus.Property, error) {
+		n, err := parseByteSize(value)
+		if err != nil {
+			return sddbus.Property{}, fmt.Errorf("%s: %v", name, err)
+		}
+		return sddbus.Property{Name: name, Value: dbus.MakeVariant(n)}, nil
+	}
+}
+
+func parseByteSize(value string) (uint64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, fmt.Errorf("empty byte size")
+	}
+	if strings.EqualFold(value, "infinity") {
+		return math.MaxUint64, nil
+	}
+
+	units := map[byte]uint64{'K': 1 << 10, 'M': 1 << 20, 'G': 1 << 30, 'T': 1 << 40}
+	suffix := value[len(value)-1]
+	if mult, ok := units[strings.ToUpper(string(suffix))[0]]; ok {
+		n, err := strconv.ParseUint(value[:len(value)-1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid byte size %q: %v", value, err)
+		}
+		if n > math.MaxUint64/mult {
+			return 0, fmt.Errorf("byte size %q overflows uint64", value)
+		}
+		return n * mult, nil
+	}
+
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size %q: %v", value, err)
+	}
+	return n, nil
+}
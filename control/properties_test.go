@@ -0,0 +1,82 @@
+package control
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    uint64
+		wantErr bool
+	}{
+		{name: "empty", value: "", wantErr: true},
+		{name: "invalid", value: "not-a-size", wantErr: true},
+		{name: "infinity", value: "infinity", want: math.MaxUint64},
+		{name: "infinity case insensitive", value: "INFINITY", want: math.MaxUint64},
+		{name: "plain bytes", value: "512", want: 512},
+		{name: "kilobytes", value: "4K", want: 4 << 10},
+		{name: "megabytes", value: "500M", want: 500 << 20},
+		{name: "gigabytes", value: "2G", want: 2 << 30},
+		{name: "terabytes", value: "1T", want: 1 << 40},
+		{name: "suffix with invalid number", value: "xM", wantErr: true},
+		{name: "overflow on multiply", value: "20000000T", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseByteSize(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseByteSize(%q) = %d, want error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseByteSize(%q) returned unexpected error: %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Fatalf("parseByteSize(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildProperty(t *testing.T) {
+	tests := []struct {
+		name     string
+		property string
+		value    string
+		wantErr  bool
+	}{
+		{name: "unsupported property", property: "ExecStart", value: "x", wantErr: true},
+		{name: "bool property valid", property: "CPUAccounting", value: "true"},
+		{name: "bool property invalid", property: "CPUAccounting", value: "not-a-bool", wantErr: true},
+		{name: "duration property valid", property: "CPUQuotaPerSecUSec", value: "500ms"},
+		{name: "duration property invalid", property: "CPUQuotaPerSecUSec", value: "not-a-duration", wantErr: true},
+		{name: "byte property valid", property: "MemoryHigh", value: "500M"},
+		{name: "byte property empty", property: "MemoryMax", value: "", wantErr: true},
+		{name: "byte property overflow", property: "MemoryMax", value: "20000000T", wantErr: true},
+		{name: "duration property negative", property: "CPUQuotaPerSecUSec", value: "-1h", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prop, err := buildProperty(tt.property, tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("buildProperty(%q, %q) = %v, want error", tt.property, tt.value, prop)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildProperty(%q, %q) returned unexpected error: %v", tt.property, tt.value, err)
+			}
+			if prop.Name != tt.property {
+				t.Fatalf("buildProperty(%q, %q).Name = %q, want %q", tt.property, tt.value, prop.Name, tt.property)
+			}
+		})
+	}
+}